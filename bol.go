@@ -0,0 +1,362 @@
+package estes
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/xml"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+//bill of lading api urls
+const (
+	bolTestURL       = "https://apitest.estes-express.com/tools/bol/generate/v1.0"
+	bolProductionURL = "https://api.estes-express.com/tools/bol/generate/v1.0"
+)
+
+//bolURL is set to the test URL by default
+//This is changed to the production URL when the SetProductionMode function is called
+var bolURL = bolTestURL
+
+//document retrieval api urls
+const (
+	documentTestURL       = "https://apitest.estes-express.com/tools/document/retrieval/v1.0"
+	documentProductionURL = "https://api.estes-express.com/tools/document/retrieval/v1.0"
+)
+
+//documentURL is set to the test URL by default
+//This is changed to the production URL when the SetProductionMode function is called
+var documentURL = documentTestURL
+
+const bolAttr = "http://estesbol.base.ws.provider.soapws.generateBOL"
+const documentAttr = "http://estesdocument.base.ws.provider.soapws.getDocument"
+
+//BOL is the main body of the xml request to generate a bill of lading
+type BOL struct {
+	XMLName xml.Name `xml:"soapenv:Envelope"`
+
+	SoapenvAttr string `xml:"xmlns:soapenv,attr"`
+	EstAttr     string `xml:"xmlns:est,attr"`
+
+	BOLRequest BOLRequest `xml:"soapenv:Body>est:generateBOLWS>bolRequest"`
+}
+
+//BOLRequest is the data needed to generate a bill of lading
+type BOLRequest struct {
+	//required
+	Shipper     Shipper        `xml:"shipper"`
+	Consignee   Consignee      `xml:"consignee"`
+	Commodities []BOLCommodity `xml:"commodityLine"`
+
+	//optional
+	ThirdPartyBilling   ThirdPartyBilling `xml:"thirdPartyBilling"`
+	SpecialInstructions string            `xml:"specialInstructions"`
+	ReferenceNumbers    []string          `xml:"referenceNumber"`
+}
+
+//Consignee is data on where a shipment is going
+type Consignee struct {
+	//required
+	ConsigneeName string `xml:"consigneeName"`
+
+	//optional
+	ConsigneeAddress Address `xml:"consigneeAddress>addressInfo"`
+	ConsigneeContact Contact `xml:"consigneeContacts>consigneeContact"`
+}
+
+//ThirdPartyBilling is data on who to bill when it's not the shipper or consignee
+type ThirdPartyBilling struct {
+	BillToName    string  `xml:"billToName"`
+	BillToAddress Address `xml:"billToAddress>addressInfo"`
+}
+
+//BOLCommodity is a single line item of freight listed on a bill of lading
+type BOLCommodity struct {
+	//required
+	Class       string  `xml:"class"`
+	Weight      float64 `xml:"weight"`
+	Pieces      uint    `xml:"pieces"`
+	Description string  `xml:"description"`
+
+	//optional
+	NMFC       string `xml:"nmfc"`
+	HazMat     bool   `xml:"hazMat"`
+	HazMatInfo string `xml:"hazMatInfo"`
+}
+
+//BOLResponse is the bill of lading confirmation data
+type BOLResponse struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		Response struct {
+			BOLNumber string `xml:"bolNumber"`
+			PDFBase64 string `xml:"pdf"`
+			URL       string `xml:"url"`
+		} `xml:"generateBOLWSResponse"`
+	} `xml:"Body"`
+}
+
+//ErrorBOLRequest is the format of an error returned when generating a bill of lading
+type ErrorBOLRequest struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        string   `xml:"code"`
+	Description string   `xml:"description"`
+	BadData     string   `xml:"badData"`
+}
+
+//Generate performs the call to the estes api to generate a bill of lading.
+//The returned pdf is populated when Estes returns the BOL inline; if Estes instead returns a URL to fetch
+//it from, pdf will be nil and the returned url will be set.
+func (b *BOLRequest) Generate(estesUsername, estesPassword string) (bolNumber string, pdf []byte, url string, err error) {
+	return b.GenerateContext(context.Background(), estesUsername, estesPassword)
+}
+
+//GenerateContext is the same as Generate but takes a context so the caller can cancel or time out the request
+func (b *BOLRequest) GenerateContext(ctx context.Context, estesUsername, estesPassword string) (bolNumber string, pdf []byte, url string, err error) {
+	//build the complete bol request object
+	bol := BOL{
+		SoapenvAttr: soapenvAttr,
+		EstAttr:     bolAttr,
+		BOLRequest:  *b,
+	}
+
+	//convert the bol request to an xml
+	xmlBytes, err := xml.Marshal(bol)
+	if err != nil {
+		err = errors.Wrap(err, "estes.Generate - could not marshal xml")
+		return
+	}
+
+	log.Println(string(xmlBytes))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", bolURL, bytes.NewReader(xmlBytes))
+	if err != nil {
+		err = errors.Wrap(err, "estes.Generate - could not build request")
+		return
+	}
+
+	req.SetBasicAuth(estesUsername, estesPassword)
+	req.Header.Add("Content-Type", "text/xml")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		//connection errors, timeouts, etc. are worth retrying
+		err = &RetryableError{Err: errors.Wrap(err, "estes.Generate - could not make post request")}
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		err = &RetryableError{Err: errors.Errorf("estes.Generate - server error, status code %d", res.StatusCode)}
+		return
+	}
+
+	//read the response
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		err = errors.Wrap(err, "estes.Generate - could not read response 1")
+		return
+	}
+
+	var responseData BOLResponse
+	err = xml.Unmarshal(body, &responseData)
+	if err != nil {
+		log.Println(string(body))
+		err = errors.Wrap(err, "estes.Generate - could not read response 2")
+		return
+	}
+
+	//check if data was returned meaning request was successful
+	//if not, reread the response data and log it
+	if responseData.Body.Response.BOLNumber == "" {
+		log.Println("estes.Generate - bol request failed")
+		log.Printf(string(body))
+
+		var errorData ErrorBOLRequest
+		xml.Unmarshal(body, &errorData)
+
+		if isRetryableFaultCode(errorData.Code) {
+			err = &RetryableError{Err: errors.Errorf("estes.Generate - bol request failed, code %s", errorData.Code)}
+		} else {
+			err = errors.New("estes.Generate - bol request failed")
+		}
+		log.Println(errorData)
+		return
+	}
+
+	bolNumber = responseData.Body.Response.BOLNumber
+	url = responseData.Body.Response.URL
+
+	if responseData.Body.Response.PDFBase64 != "" {
+		pdf, err = base64.StdEncoding.DecodeString(responseData.Body.Response.PDFBase64)
+		if err != nil {
+			err = errors.Wrap(err, "estes.Generate - could not decode pdf")
+			return
+		}
+	}
+
+	//bol generation successful
+	return
+}
+
+//GenerateWithRetry is Generate with retries, using DefaultRetryPolicy
+func (b *BOLRequest) GenerateWithRetry(estesUsername, estesPassword string) (bolNumber string, pdf []byte, url string, err error) {
+	return b.GenerateWithRetryPolicy(context.Background(), estesUsername, estesPassword, DefaultRetryPolicy)
+}
+
+//GenerateWithRetryPolicy is Generate with retries using a caller-supplied policy and context
+func (b *BOLRequest) GenerateWithRetryPolicy(ctx context.Context, estesUsername, estesPassword string, policy RetryPolicy) (bolNumber string, pdf []byte, url string, err error) {
+	err = doWithRetry(ctx, policy, func() error {
+		var attemptErr error
+		bolNumber, pdf, url, attemptErr = b.GenerateContext(ctx, estesUsername, estesPassword)
+		return attemptErr
+	})
+
+	return
+}
+
+//DocType is the kind of document being fetched with GetDocument
+type DocType string
+
+//document types Estes can return for a PRO number
+const (
+	DocTypeDeliveryReceipt      DocType = "DELIVERY_RECEIPT"
+	DocTypeWeightInspectionCert DocType = "WEIGHT_INSPECTION_CERT"
+)
+
+//GetDocumentRequest is the data needed to fetch a document for a shipment
+type GetDocumentRequest struct {
+	XMLName xml.Name `xml:"soapenv:Envelope"`
+
+	SoapenvAttr string `xml:"xmlns:soapenv,attr"`
+	EstAttr     string `xml:"xmlns:est,attr"`
+
+	PRONumber string  `xml:"soapenv:Body>est:getDocumentWS>proNumber"`
+	DocType   DocType `xml:"soapenv:Body>est:getDocumentWS>docType"`
+}
+
+//GetDocumentResponse is the format of the data returned from the document retrieval service
+type GetDocumentResponse struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		Response struct {
+			ContentType string `xml:"contentType"`
+			DataBase64  string `xml:"data"`
+		} `xml:"getDocumentWSResponse"`
+	} `xml:"Body"`
+}
+
+//ErrorGetDocument is the format of an error returned when fetching a document
+type ErrorGetDocument struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        string   `xml:"code"`
+	Description string   `xml:"description"`
+	BadData     string   `xml:"badData"`
+}
+
+//GetDocument fetches a delivery receipt or weight/inspection certificate for a PRO number,
+//returning the raw document bytes and the content type Estes reports for them (e.g. "application/pdf").
+func GetDocument(proNumber string, docType DocType, estesUsername, estesPassword string) (data []byte, contentType string, err error) {
+	return GetDocumentContext(context.Background(), proNumber, docType, estesUsername, estesPassword)
+}
+
+//GetDocumentContext is the same as GetDocument but takes a context so the caller can cancel or time out the request
+func GetDocumentContext(ctx context.Context, proNumber string, docType DocType, estesUsername, estesPassword string) (data []byte, contentType string, err error) {
+	request := GetDocumentRequest{
+		SoapenvAttr: soapenvAttr,
+		EstAttr:     documentAttr,
+		PRONumber:   proNumber,
+		DocType:     docType,
+	}
+
+	xmlBytes, err := xml.Marshal(request)
+	if err != nil {
+		err = errors.Wrap(err, "estes.GetDocument - could not marshal xml")
+		return
+	}
+
+	log.Println(string(xmlBytes))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", documentURL, bytes.NewReader(xmlBytes))
+	if err != nil {
+		err = errors.Wrap(err, "estes.GetDocument - could not build request")
+		return
+	}
+
+	req.SetBasicAuth(estesUsername, estesPassword)
+	req.Header.Add("Content-Type", "text/xml")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		//connection errors, timeouts, etc. are worth retrying
+		err = &RetryableError{Err: errors.Wrap(err, "estes.GetDocument - could not make post request")}
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		err = &RetryableError{Err: errors.Errorf("estes.GetDocument - server error, status code %d", res.StatusCode)}
+		return
+	}
+
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		err = errors.Wrap(err, "estes.GetDocument - could not read response 1")
+		return
+	}
+
+	var responseData GetDocumentResponse
+	err = xml.Unmarshal(body, &responseData)
+	if err != nil {
+		log.Println(string(body))
+		err = errors.Wrap(err, "estes.GetDocument - could not read response 2")
+		return
+	}
+
+	if responseData.Body.Response.DataBase64 == "" {
+		log.Println("estes.GetDocument - get document request failed")
+		log.Printf(string(body))
+
+		var errorData ErrorGetDocument
+		xml.Unmarshal(body, &errorData)
+
+		if isRetryableFaultCode(errorData.Code) {
+			err = &RetryableError{Err: errors.Errorf("estes.GetDocument - get document request failed, code %s", errorData.Code)}
+		} else {
+			err = errors.New("estes.GetDocument - get document request failed")
+		}
+		log.Println(errorData)
+		return
+	}
+
+	data, err = base64.StdEncoding.DecodeString(responseData.Body.Response.DataBase64)
+	if err != nil {
+		err = errors.Wrap(err, "estes.GetDocument - could not decode document data")
+		return
+	}
+
+	contentType = responseData.Body.Response.ContentType
+
+	//document fetch successful
+	return
+}
+
+//GetDocumentWithRetry is GetDocument with retries, using DefaultRetryPolicy
+func GetDocumentWithRetry(proNumber string, docType DocType, estesUsername, estesPassword string) (data []byte, contentType string, err error) {
+	return GetDocumentWithRetryPolicy(context.Background(), proNumber, docType, estesUsername, estesPassword, DefaultRetryPolicy)
+}
+
+//GetDocumentWithRetryPolicy is GetDocument with retries using a caller-supplied policy and context
+func GetDocumentWithRetryPolicy(ctx context.Context, proNumber string, docType DocType, estesUsername, estesPassword string, policy RetryPolicy) (data []byte, contentType string, err error) {
+	err = doWithRetry(ctx, policy, func() error {
+		var attemptErr error
+		data, contentType, attemptErr = GetDocumentContext(ctx, proNumber, docType, estesUsername, estesPassword)
+		return attemptErr
+	})
+
+	return
+}
@@ -6,18 +6,28 @@ You will need to have a Estes account and register for access to use this.
 
 Currently this package can perform:
 - pickup requests
+- rate quotes (LTL rating)
+- shipment tracking, including polling for status changes
+- bill of lading generation and retrieving delivered documents (delivery receipts, inspection certs)
 
 To create a pickup request:
 - Set test or production mode (SetProductionMode()).
 - Set shipper information.
 - Set shipment data.
-- Request the pickup.
+- Request the pickup, or RequestPickupWithRetry if Estes' API is flaky for you.
+- Check for any errors.
+
+To get a rate quote:
+- Set test or production mode (SetProductionMode()).
+- Set origin, destination, and commodity data.
+- Call Quote().
 - Check for any errors.
 */
 package estes
 
 import (
 	"bytes"
+	"context"
 	"encoding/xml"
 	"io/ioutil"
 	"log"
@@ -44,6 +54,12 @@ var estesURL = estesTestURL
 //10 seconds is overly long, but sometimes Ward is very slow.
 var timeout = time.Duration(10 * time.Second)
 
+//httpClient is used for every call this package makes
+//Use SetHTTPClient to provide your own client (connection pooling, proxies, TLS config, tracing, etc.)
+var httpClient = &http.Client{
+	Timeout: timeout,
+}
+
 //base XML data
 const (
 	soapenvAttr = "http://schemas.xmlsoap.org/soap/envelope/"
@@ -142,19 +158,38 @@ type ErrorPickupRequest struct {
 func SetProductionMode(yes bool) {
 	if yes {
 		estesURL = estesProductionURL
+		rateQuoteURL = rateQuoteProductionURL
+		trackingURL = trackingProductionURL
+		bolURL = bolProductionURL
+		documentURL = documentProductionURL
 	}
 	return
 }
 
 //SetTimeout updates the timeout value to something the user sets
 //use this to increase the timeout if connecting to Ward is really slow
+//seconds is already a time.Duration (e.g. 10*time.Second), do not multiply it by time.Second again
 func SetTimeout(seconds time.Duration) {
-	timeout = time.Duration(seconds * time.Second)
+	timeout = seconds
+	httpClient.Timeout = timeout
+	return
+}
+
+//SetHTTPClient lets the caller provide their own http.Client, for connection pooling, proxies,
+//custom TLS config, tracing, or anything else the default client doesn't cover.
+func SetHTTPClient(c *http.Client) {
+	httpClient = c
 	return
 }
 
 //RequestPickup performs the call to the estes api to schedule a pickup
 func (p *PickupRequestInput) RequestPickup(estesUsername, estesPassword string) (responseData SuccessfulPickupRequest, err error) {
+	return p.RequestPickupContext(context.Background(), estesUsername, estesPassword)
+}
+
+//RequestPickupContext is the same as RequestPickup but takes a context so the caller can cancel
+//or time out the request (e.g. context.WithTimeout).
+func (p *PickupRequestInput) RequestPickupContext(ctx context.Context, estesUsername, estesPassword string) (responseData SuccessfulPickupRequest, err error) {
 	//build the complete pickup request object
 	pickup := PickupRequest{
 		SoapenvAttr:        soapenvAttr,
@@ -169,15 +204,9 @@ func (p *PickupRequestInput) RequestPickup(estesUsername, estesPassword string)
 		return
 	}
 
-	//make the call to the estes API
-	//set a timeout since golang doesn't set one by default and we don't want this to hang forever
-	httpClient := http.Client{
-		Timeout: timeout,
-	}
-
 	log.Println(string(xmlBytes))
 
-	req, err := http.NewRequest("POST", estesProductionURL, bytes.NewReader(xmlBytes))
+	req, err := http.NewRequestWithContext(ctx, "POST", estesURL, bytes.NewReader(xmlBytes))
 	if err != nil {
 		err = errors.Wrap(err, "estes.RequestPickup - could not make build request")
 		return
@@ -188,13 +217,19 @@ func (p *PickupRequestInput) RequestPickup(estesUsername, estesPassword string)
 
 	res, err := httpClient.Do(req)
 	if err != nil {
-		err = errors.Wrap(err, "estes.RequestPickup - could not make post request")
+		//connection errors, timeouts, etc. are worth retrying
+		err = &RetryableError{Err: errors.Wrap(err, "estes.RequestPickup - could not make post request")}
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		err = &RetryableError{Err: errors.Errorf("estes.RequestPickup - server error, status code %d", res.StatusCode)}
 		return
 	}
 
 	//read the response
 	body, err := ioutil.ReadAll(res.Body)
-	defer res.Body.Close()
 	if err != nil {
 		err = errors.Wrap(err, "estes.RequestPickup - could not read response 1")
 		return
@@ -216,7 +251,11 @@ func (p *PickupRequestInput) RequestPickup(estesUsername, estesPassword string)
 		var errorData ErrorPickupRequest
 		xml.Unmarshal(body, &errorData)
 
-		err = errors.New("estes.RequestPickup - pickup request failed")
+		if isRetryableFaultCode(errorData.Code) {
+			err = &RetryableError{Err: errors.Errorf("estes.RequestPickup - pickup request failed, code %s", errorData.Code)}
+		} else {
+			err = errors.New("estes.RequestPickup - pickup request failed")
+		}
 		log.Println(errorData)
 		return
 	}
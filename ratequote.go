@@ -0,0 +1,204 @@
+package estes
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"log"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+//rate quoting api urls
+const (
+	rateQuoteTestURL       = "https://apitest.estes-express.com/tools/rate/quote/v1.0"
+	rateQuoteProductionURL = "https://api.estes-express.com/tools/rate/quote/v1.0"
+)
+
+//rateQuoteURL is set to the test URL by default
+//This is changed to the production URL when the SetProductionMode function is called
+var rateQuoteURL = rateQuoteTestURL
+
+const rateAttr = "http://estesrating.base.ws.provider.soapws.rateQuote"
+
+//RateQuote is the main body of the xml request for a rate quote
+type RateQuote struct {
+	XMLName xml.Name `xml:"soapenv:Envelope"`
+
+	SoapenvAttr string `xml:"xmlns:soapenv,attr"`
+	EstAttr     string `xml:"xmlns:est,attr"`
+
+	RateQuoteRequest RateQuoteRequest `xml:"soapenv:Body>est:createRateQuoteWS>rateQuoteRequest"`
+}
+
+//RateQuoteRequest is the data needed to get an LTL rate quote
+type RateQuoteRequest struct {
+	//required
+	Origin      Address      `xml:"origin>addressInfo"`
+	Destination Address      `xml:"destination>addressInfo"`
+	Commodities []Commodity  `xml:"commodityLine"`
+	ShipDate    string       `xml:"shipDate"` //yyyy-mm-dd
+	Payment     PaymentTerms `xml:"paymentTerms"`
+
+	//optional
+	Accessorials []string `xml:"accessorial"`
+}
+
+//PaymentTerms is who pays for the shipment
+type PaymentTerms string
+
+//payment terms Estes accepts on a rate quote
+const (
+	PaymentTermsPrepaid  PaymentTerms = "PPD"
+	PaymentTermsCollect  PaymentTerms = "COL"
+	PaymentTermsThirdPty PaymentTerms = "TPB"
+)
+
+//Commodity is a single line item of freight being shipped
+type Commodity struct {
+	//required
+	Class  string  `xml:"class"`
+	Weight float64 `xml:"weight"`
+	Pieces uint    `xml:"pieces"`
+
+	//optional
+	HazMat     bool   `xml:"hazMat"`
+	NMFC       string `xml:"nmfc"`
+	Dimensions string `xml:"dimensions"` //LxWxH in inches
+}
+
+//RateQuoteResponse is the rate quote confirmation data
+type RateQuoteResponse struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		Response struct {
+			Total              float64             `xml:"total"`
+			FuelSurcharge      float64             `xml:"fuelSurcharge"`
+			TransitDays        uint                `xml:"transitDays"`
+			AccessorialCharges []AccessorialCharge `xml:"accessorialCharge"`
+			GuaranteedOptions  []GuaranteedOption  `xml:"guaranteedOption"`
+		} `xml:"createRateQuoteWSResponse"`
+	} `xml:"Body"`
+}
+
+//AccessorialCharge is a single line item charge on a rate quote
+type AccessorialCharge struct {
+	Code        string  `xml:"code"`
+	Description string  `xml:"description"`
+	Amount      float64 `xml:"amount"`
+}
+
+//GuaranteedOption is a guaranteed service level offered along with its added cost
+type GuaranteedOption struct {
+	Code        string  `xml:"code"`
+	Description string  `xml:"description"`
+	Amount      float64 `xml:"amount"`
+}
+
+//ErrorRateQuote is the format of an error returned when requesting a rate quote
+type ErrorRateQuote struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        string   `xml:"code"`
+	Description string   `xml:"description"`
+	BadData     string   `xml:"badData"`
+}
+
+//Quote performs the call to the estes api to get an LTL rate quote
+func (r *RateQuoteRequest) Quote(estesUsername, estesPassword string) (responseData RateQuoteResponse, err error) {
+	return r.QuoteContext(context.Background(), estesUsername, estesPassword)
+}
+
+//QuoteContext is the same as Quote but takes a context so the caller can cancel or time out the request
+func (r *RateQuoteRequest) QuoteContext(ctx context.Context, estesUsername, estesPassword string) (responseData RateQuoteResponse, err error) {
+	//build the complete rate quote request object
+	quote := RateQuote{
+		SoapenvAttr:      soapenvAttr,
+		EstAttr:          rateAttr,
+		RateQuoteRequest: *r,
+	}
+
+	//convert the rate quote request to an xml
+	xmlBytes, err := xml.Marshal(quote)
+	if err != nil {
+		err = errors.Wrap(err, "estes.Quote - could not marshal xml")
+		return
+	}
+
+	log.Println(string(xmlBytes))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", rateQuoteURL, bytes.NewReader(xmlBytes))
+	if err != nil {
+		err = errors.Wrap(err, "estes.Quote - could not build request")
+		return
+	}
+
+	req.SetBasicAuth(estesUsername, estesPassword)
+	req.Header.Add("Content-Type", "text/xml")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		//connection errors, timeouts, etc. are worth retrying
+		err = &RetryableError{Err: errors.Wrap(err, "estes.Quote - could not make post request")}
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		err = &RetryableError{Err: errors.Errorf("estes.Quote - server error, status code %d", res.StatusCode)}
+		return
+	}
+
+	//read the response
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		err = errors.Wrap(err, "estes.Quote - could not read response 1")
+		return
+	}
+
+	err = xml.Unmarshal(body, &responseData)
+	if err != nil {
+		log.Println(string(body))
+		err = errors.Wrap(err, "estes.Quote - could not read response 2")
+		return
+	}
+
+	//check if data was returned meaning request was successful
+	//if not, reread the response data and log it
+	if responseData.Body.Response.Total == 0 {
+		log.Println("estes.Quote - rate quote request failed")
+		log.Printf(string(body))
+
+		var errorData ErrorRateQuote
+		xml.Unmarshal(body, &errorData)
+
+		if isRetryableFaultCode(errorData.Code) {
+			err = &RetryableError{Err: errors.Errorf("estes.Quote - rate quote request failed, code %s", errorData.Code)}
+		} else {
+			err = errors.New("estes.Quote - rate quote request failed")
+		}
+		log.Println(errorData)
+		return
+	}
+
+	//rate quote successful
+	//response data will have pricing info
+	return
+}
+
+//QuoteWithRetry is Quote with retries, using DefaultRetryPolicy
+func (r *RateQuoteRequest) QuoteWithRetry(estesUsername, estesPassword string) (responseData RateQuoteResponse, err error) {
+	return r.QuoteWithRetryPolicy(context.Background(), estesUsername, estesPassword, DefaultRetryPolicy)
+}
+
+//QuoteWithRetryPolicy is Quote with retries using a caller-supplied policy and context
+func (r *RateQuoteRequest) QuoteWithRetryPolicy(ctx context.Context, estesUsername, estesPassword string, policy RetryPolicy) (responseData RateQuoteResponse, err error) {
+	err = doWithRetry(ctx, policy, func() error {
+		var attemptErr error
+		responseData, attemptErr = r.QuoteContext(ctx, estesUsername, estesPassword)
+		return attemptErr
+	})
+
+	return
+}
@@ -0,0 +1,183 @@
+package estes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"math"
+	mathrand "math/rand"
+	"time"
+)
+
+//retryable SOAP fault codes returned by Estes
+//these are conditions worth trying again, as opposed to a terminal problem with the request itself
+var retryableFaultCodes = map[string]bool{
+	"SYSTEM_ERROR":       true,
+	"SYSTEM_UNAVAILABLE": true,
+	"TIMEOUT":            true,
+}
+
+//RetryPolicy controls how RequestPickupWithRetry (and friends) back off between attempts
+type RetryPolicy struct {
+	//MaxAttempts is the most attempts to make, including the first one
+	MaxAttempts int
+
+	//InitialInterval is how long to wait before the first retry
+	InitialInterval time.Duration
+
+	//MaxInterval caps how long any single wait between retries can grow to
+	MaxInterval time.Duration
+
+	//Multiplier is applied to the wait after each attempt (exponential backoff)
+	Multiplier float64
+
+	//MaxElapsedTime caps the total time spent retrying, across all attempts
+	MaxElapsedTime time.Duration
+}
+
+//DefaultRetryPolicy is used by the *WithRetry methods when no policy is given
+var DefaultRetryPolicy = RetryPolicy{
+	MaxAttempts:     5,
+	InitialInterval: 500 * time.Millisecond,
+	MaxInterval:     10 * time.Second,
+	Multiplier:      2,
+	MaxElapsedTime:  2 * time.Minute,
+}
+
+//RetryableError marks an error as worth retrying (connection errors, 5xx responses, certain SOAP faults).
+//Anything not wrapped in a RetryableError is treated as terminal and returned to the caller immediately.
+type RetryableError struct {
+	Err error
+}
+
+//Error implements the error interface
+func (r *RetryableError) Error() string {
+	return r.Err.Error()
+}
+
+//Cause lets github.com/pkg/errors unwrap this back to the underlying error
+func (r *RetryableError) Cause() error {
+	return r.Err
+}
+
+//causer is the interface github.com/pkg/errors uses to unwrap a wrapped error
+type causer interface {
+	Cause() error
+}
+
+//isRetryable reports whether err is a condition worth trying again. It walks the Cause() chain
+//looking for a *RetryableError - it must check each error as it's unwrapped, not just the innermost
+//cause, since *RetryableError.Cause() itself returns the underlying error it's marking.
+func isRetryable(err error) bool {
+	for err != nil {
+		if _, ok := err.(*RetryableError); ok {
+			return true
+		}
+
+		c, ok := err.(causer)
+		if !ok {
+			return false
+		}
+
+		err = c.Cause()
+	}
+
+	return false
+}
+
+//isRetryableFaultCode reports whether a SOAP fault code returned by Estes is worth retrying
+func isRetryableFaultCode(code string) bool {
+	return retryableFaultCodes[code]
+}
+
+//doWithRetry calls fn until it succeeds, fn returns a non-retryable error, the policy's attempt or
+//elapsed time limits are hit, or ctx is cancelled. Waits between attempts use exponential backoff with
+//jitter so a fleet of clients retrying the same outage doesn't all hammer Estes at the same moment.
+func doWithRetry(ctx context.Context, policy RetryPolicy, fn func() error) error {
+	start := time.Now()
+	interval := policy.InitialInterval
+
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil {
+			return nil
+		}
+
+		if !isRetryable(err) {
+			return err
+		}
+
+		if policy.MaxAttempts > 0 && attempt >= policy.MaxAttempts {
+			return err
+		}
+
+		if policy.MaxElapsedTime > 0 && time.Since(start) >= policy.MaxElapsedTime {
+			return err
+		}
+
+		wait := jitter(interval)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+
+		interval = time.Duration(math.Min(float64(policy.MaxInterval), float64(interval)*policy.Multiplier))
+	}
+}
+
+//jitter randomizes a duration by up to +/-25% so concurrent retries spread out instead of bunching up
+func jitter(d time.Duration) time.Duration {
+	if d <= 0 {
+		return d
+	}
+
+	delta := float64(d) * 0.25
+	return d + time.Duration((mathrand.Float64()*2-1)*delta)
+}
+
+//EnsureIdempotencyKey returns p.RequestNumber, generating and storing a random one first if it isn't
+//already set. Call this before RequestPickupWithRetry and log/persist the returned key - since the
+//RequestNumber stays the same across every retried attempt, Estes won't schedule a duplicate pickup if
+//an earlier attempt actually succeeded but its response was lost.
+func (p *PickupRequestInput) EnsureIdempotencyKey() string {
+	if p.RequestNumber == "" {
+		p.RequestNumber = generateIdempotencyKey()
+	}
+
+	return p.RequestNumber
+}
+
+//generateIdempotencyKey makes a random key suitable for use as a PickupRequestInput.RequestNumber
+func generateIdempotencyKey() string {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		//crypto/rand.Read only fails if the system CSPRNG is broken, fall back to a timestamp
+		//rather than leaving the idempotency key empty
+		return hex.EncodeToString([]byte(time.Now().String()))
+	}
+
+	return hex.EncodeToString(b)
+}
+
+//RequestPickupWithRetry is RequestPickup with retries, using DefaultRetryPolicy
+func (p *PickupRequestInput) RequestPickupWithRetry(estesUsername, estesPassword string) (responseData SuccessfulPickupRequest, err error) {
+	return p.RequestPickupWithRetryPolicy(context.Background(), estesUsername, estesPassword, DefaultRetryPolicy)
+}
+
+//RequestPickupWithRetryPolicy is RequestPickup with retries using a caller-supplied policy and context.
+//It calls p.EnsureIdempotencyKey() itself before the first attempt, so every retried attempt reuses the same
+//RequestNumber; call p.EnsureIdempotencyKey() yourself beforehand if you need to log/persist the key first.
+func (p *PickupRequestInput) RequestPickupWithRetryPolicy(ctx context.Context, estesUsername, estesPassword string, policy RetryPolicy) (responseData SuccessfulPickupRequest, err error) {
+	p.EnsureIdempotencyKey()
+
+	err = doWithRetry(ctx, policy, func() error {
+		var attemptErr error
+		responseData, attemptErr = p.RequestPickupContext(ctx, estesUsername, estesPassword)
+		return attemptErr
+	})
+
+	return
+}
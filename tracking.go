@@ -0,0 +1,253 @@
+package estes
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+//shipment tracking api urls
+const (
+	trackingTestURL       = "https://apitest.estes-express.com/tools/tracking/shipment/v1.0"
+	trackingProductionURL = "https://api.estes-express.com/tools/tracking/shipment/v1.0"
+)
+
+//trackingURL is set to the test URL by default
+//This is changed to the production URL when the SetProductionMode function is called
+var trackingURL = trackingTestURL
+
+const trackAttr = "http://estestracking.base.ws.provider.soapws.trackShipment"
+
+//TrackShipment is the main body of the xml request to track one or more shipments
+type TrackShipment struct {
+	XMLName xml.Name `xml:"soapenv:Envelope"`
+
+	SoapenvAttr string `xml:"xmlns:soapenv,attr"`
+	EstAttr     string `xml:"xmlns:est,attr"`
+
+	TrackRequest TrackRequest `xml:"soapenv:Body>est:trackShipmentWS>trackRequest"`
+}
+
+//TrackRequest is the list of PRO numbers to look up the status of
+type TrackRequest struct {
+	PRONumbers []string `xml:"proNumber"`
+}
+
+//TrackResponse is the format of the data returned from the tracking service
+type TrackResponse struct {
+	XMLName xml.Name `xml:"Envelope"`
+	Body    struct {
+		Response struct {
+			Shipments []ShipmentStatus `xml:"shipmentStatus"`
+		} `xml:"trackShipmentWSResponse"`
+	} `xml:"Body"`
+}
+
+//ShipmentStatus is the current status of a single shipment
+type ShipmentStatus struct {
+	PRONumber      string        `xml:"proNumber"`
+	StatusCode     string        `xml:"statusCode"`
+	StatusDesc     string        `xml:"statusDescription"`
+	OriginTerminal string        `xml:"originTerminal"`
+	DestTerminal   string        `xml:"destinationTerminal"`
+	PickupDate     string        `xml:"pickupDate"`
+	DeliveryDate   string        `xml:"deliveryDate"`
+	Weight         float64       `xml:"weight"`
+	Pieces         uint          `xml:"pieces"`
+	Events         []StatusEvent `xml:"statusEvent"`
+}
+
+//StatusEvent is a single event in a shipment's tracking history
+type StatusEvent struct {
+	Timestamp   string `xml:"timestamp"`
+	Location    string `xml:"location"`
+	Code        string `xml:"code"`
+	Description string `xml:"description"`
+}
+
+//ErrorTrackRequest is the format of an error returned when tracking shipments
+type ErrorTrackRequest struct {
+	XMLName     xml.Name `xml:"error"`
+	Code        string   `xml:"code"`
+	Description string   `xml:"description"`
+	BadData     string   `xml:"badData"`
+}
+
+//Track performs the call to the estes api to look up the status of one or more PRO numbers
+func (t *TrackRequest) Track(estesUsername, estesPassword string) (shipments []ShipmentStatus, err error) {
+	return t.TrackContext(context.Background(), estesUsername, estesPassword)
+}
+
+//TrackContext is the same as Track but takes a context so the caller can cancel or time out the request
+func (t *TrackRequest) TrackContext(ctx context.Context, estesUsername, estesPassword string) (shipments []ShipmentStatus, err error) {
+	//build the complete tracking request object
+	track := TrackShipment{
+		SoapenvAttr:  soapenvAttr,
+		EstAttr:      trackAttr,
+		TrackRequest: *t,
+	}
+
+	//convert the tracking request to an xml
+	xmlBytes, err := xml.Marshal(track)
+	if err != nil {
+		err = errors.Wrap(err, "estes.Track - could not marshal xml")
+		return
+	}
+
+	log.Println(string(xmlBytes))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", trackingURL, bytes.NewReader(xmlBytes))
+	if err != nil {
+		err = errors.Wrap(err, "estes.Track - could not build request")
+		return
+	}
+
+	req.SetBasicAuth(estesUsername, estesPassword)
+	req.Header.Add("Content-Type", "text/xml")
+
+	res, err := httpClient.Do(req)
+	if err != nil {
+		//connection errors, timeouts, etc. are worth retrying
+		err = &RetryableError{Err: errors.Wrap(err, "estes.Track - could not make post request")}
+		return
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode >= 500 {
+		err = &RetryableError{Err: errors.Errorf("estes.Track - server error, status code %d", res.StatusCode)}
+		return
+	}
+
+	//read the response
+	body, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		err = errors.Wrap(err, "estes.Track - could not read response 1")
+		return
+	}
+
+	var responseData TrackResponse
+	err = xml.Unmarshal(body, &responseData)
+	if err != nil {
+		log.Println(string(body))
+		err = errors.Wrap(err, "estes.Track - could not read response 2")
+		return
+	}
+
+	//check if data was returned meaning request was successful
+	//if not, reread the response data and log it
+	if len(responseData.Body.Response.Shipments) == 0 {
+		log.Println("estes.Track - track request failed")
+		log.Printf(string(body))
+
+		var errorData ErrorTrackRequest
+		xml.Unmarshal(body, &errorData)
+
+		if isRetryableFaultCode(errorData.Code) {
+			err = &RetryableError{Err: errors.Errorf("estes.Track - track request failed, code %s", errorData.Code)}
+		} else {
+			err = errors.New("estes.Track - track request failed")
+		}
+		log.Println(errorData)
+		return
+	}
+
+	//tracking successful
+	shipments = responseData.Body.Response.Shipments
+	return
+}
+
+//TrackWithRetry is Track with retries, using DefaultRetryPolicy
+func (t *TrackRequest) TrackWithRetry(estesUsername, estesPassword string) (shipments []ShipmentStatus, err error) {
+	return t.TrackWithRetryPolicy(context.Background(), estesUsername, estesPassword, DefaultRetryPolicy)
+}
+
+//TrackWithRetryPolicy is Track with retries using a caller-supplied policy and context
+func (t *TrackRequest) TrackWithRetryPolicy(ctx context.Context, estesUsername, estesPassword string, policy RetryPolicy) (shipments []ShipmentStatus, err error) {
+	err = doWithRetry(ctx, policy, func() error {
+		var attemptErr error
+		shipments, attemptErr = t.TrackContext(ctx, estesUsername, estesPassword)
+		return attemptErr
+	})
+
+	return
+}
+
+//Tracker polls a set of PRO numbers on an interval and notifies a callback of any status transitions.
+//Build one with NewTracker, then call Start to begin polling.
+type Tracker struct {
+	PRONumbers []string
+	Interval   time.Duration
+	Username   string
+	Password   string
+
+	//OnTransition is called whenever a tracked PRO's status changes
+	//old is the zero value ShipmentStatus the first time a PRO is seen
+	OnTransition func(old, new ShipmentStatus)
+
+	known map[string]ShipmentStatus
+}
+
+//NewTracker builds a Tracker for the given PRO numbers that polls on the given interval
+func NewTracker(proNumbers []string, interval time.Duration, username, password string, onTransition func(old, new ShipmentStatus)) *Tracker {
+	return &Tracker{
+		PRONumbers:   proNumbers,
+		Interval:     interval,
+		Username:     username,
+		Password:     password,
+		OnTransition: onTransition,
+		known:        make(map[string]ShipmentStatus),
+	}
+}
+
+//Start polls Estes on the configured interval until ctx is cancelled
+//Each tick, any PRO whose status differs from the last known status triggers a call to OnTransition.
+func (t *Tracker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(t.Interval)
+	defer ticker.Stop()
+
+	//poll once immediately so callers don't wait a full interval for the first update
+	//a failure here is treated the same as any other tick's failure - log it and keep polling,
+	//rather than letting a single transient hiccup kill the whole Tracker
+	if err := t.poll(ctx); err != nil {
+		log.Println("estes.Tracker.Start - could not poll", err)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			if err := t.poll(ctx); err != nil {
+				log.Println("estes.Tracker.Start - could not poll", err)
+			}
+		}
+	}
+}
+
+//poll looks up the current status of every tracked PRO and diffs it against what was last seen
+func (t *Tracker) poll(ctx context.Context) error {
+	request := TrackRequest{PRONumbers: t.PRONumbers}
+	shipments, err := request.TrackContext(ctx, t.Username, t.Password)
+	if err != nil {
+		return err
+	}
+
+	for _, shipment := range shipments {
+		previous, seen := t.known[shipment.PRONumber]
+		t.known[shipment.PRONumber] = shipment
+
+		if !seen || previous.StatusCode != shipment.StatusCode {
+			if t.OnTransition != nil {
+				t.OnTransition(previous, shipment)
+			}
+		}
+	}
+
+	return nil
+}